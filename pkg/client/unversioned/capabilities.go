@@ -0,0 +1,136 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/kubernetes/pkg/client/unversioned/discovery"
+)
+
+// DefaultCapabilitiesTTL is how long a Capabilities snapshot is trusted
+// before Client.Capabilities() re-queries the server.
+const DefaultCapabilitiesTTL = 10 * time.Minute
+
+// APIVersions reports which group/versions and group/version/kinds the
+// server has actually registered, as opposed to merely compiled-in support
+// on the client side.
+type APIVersions struct {
+	versions  map[string]bool
+	resources map[string]bool
+}
+
+// Has reports whether the given group/version (e.g. "apps/v1") is served.
+func (v *APIVersions) Has(groupVersion string) bool {
+	if v == nil {
+		return false
+	}
+	return v.versions[groupVersion]
+}
+
+// HasResource reports whether the given group/version/kind (e.g.
+// "apps", "v1", "Deployment") has been registered on the server. An empty
+// group means the legacy core group.
+func (v *APIVersions) HasResource(group, version, kind string) bool {
+	if v == nil {
+		return false
+	}
+	groupVersion := version
+	if group != "" {
+		groupVersion = group + "/" + version
+	}
+	return v.resources[groupVersion+"/"+kind]
+}
+
+// Capabilities is a cached, concurrency-safe view of the server's
+// advertised API surface, suitable for gating manifests or admission logic
+// on whether a group/version or a specific CRD kind actually exists on the
+// cluster the client is talking to. It is backed by the discovery
+// subsystem rather than ServerAPIVersions, which only ever reports the
+// legacy core v1 group and would never see e.g. "apps/v1".
+type Capabilities struct {
+	discovery discovery.DiscoveryInterface
+	ttl       time.Duration
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	versions  *APIVersions
+}
+
+// NewCapabilities returns a Capabilities checker backed by d, caching
+// results for ttl before refreshing. A ttl of zero or less keeps the cached
+// result until Invalidate is called.
+func NewCapabilities(d discovery.DiscoveryInterface, ttl time.Duration) *Capabilities {
+	return &Capabilities{discovery: d, ttl: ttl}
+}
+
+// Capabilities returns the Capabilities checker for this Client, using
+// DefaultCapabilitiesTTL. The same instance is returned on every call so
+// its TTL cache is actually shared across call sites, mirroring how
+// Discovery() memoizes its discovery client.
+func (c *Client) Capabilities() *Capabilities {
+	c.capabilitiesOnce.Do(func() {
+		c.capabilities = NewCapabilities(c.Discovery(), DefaultCapabilitiesTTL)
+	})
+	return c.capabilities
+}
+
+// APIVersions returns the current (possibly cached) view of the server's
+// advertised API surface, lazily populating or refreshing it as needed.
+func (c *Capabilities) APIVersions() (*APIVersions, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.versions != nil && (c.ttl <= 0 || time.Since(c.fetchedAt) < c.ttl) {
+		return c.versions, nil
+	}
+
+	// ServerResources enumerates every group/version the server advertises
+	// through /apis as well as the legacy /api v1 group, unlike
+	// ServerAPIVersions which only ever sees v1. A non-empty result map
+	// means at least some group/versions resolved even if others failed
+	// (the error is an aggregate of the rest) and is used as is; an error
+	// with nothing resolved means discovery itself couldn't be reached.
+	resourcesByGroupVersion, err := c.discovery.ServerResources()
+	if err != nil && len(resourcesByGroupVersion) == 0 {
+		return nil, err
+	}
+
+	v := &APIVersions{
+		versions:  map[string]bool{},
+		resources: map[string]bool{},
+	}
+	for groupVersion, list := range resourcesByGroupVersion {
+		v.versions[groupVersion] = true
+		for _, resource := range list.APIResources {
+			v.resources[groupVersion+"/"+resource.Kind] = true
+		}
+	}
+
+	c.versions = v
+	c.fetchedAt = time.Now()
+	return c.versions, nil
+}
+
+// Invalidate forces the next APIVersions call to re-query the server,
+// regardless of the configured TTL.
+func (c *Capabilities) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.versions = nil
+}
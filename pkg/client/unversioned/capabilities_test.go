@@ -0,0 +1,140 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/discovery"
+)
+
+// fakeDiscovery is a minimal discovery.DiscoveryInterface that reports both
+// the legacy core group and a non-core group, so tests can confirm
+// Capabilities actually sees groups ServerAPIVersions alone would miss.
+type fakeDiscovery struct {
+	calls     int
+	resources map[string]*unversioned.APIResourceList
+}
+
+func newFakeDiscovery() *fakeDiscovery {
+	return &fakeDiscovery{
+		resources: map[string]*unversioned.APIResourceList{
+			"v1": {
+				GroupVersion: "v1",
+				APIResources: []unversioned.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true}},
+			},
+			"apps/v1": {
+				GroupVersion: "apps/v1",
+				APIResources: []unversioned.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+			},
+		},
+	}
+}
+
+func (f *fakeDiscovery) ServerGroups() (*unversioned.APIGroupList, error) {
+	return nil, nil
+}
+
+func (f *fakeDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error) {
+	return f.resources[groupVersion], nil
+}
+
+func (f *fakeDiscovery) ServerResources() (map[string]*unversioned.APIResourceList, error) {
+	f.calls++
+	return f.resources, nil
+}
+
+func (f *fakeDiscovery) ServerPreferredResources() (map[string]*unversioned.APIResourceList, error) {
+	return f.resources, nil
+}
+
+func (f *fakeDiscovery) ServerPreferredNamespacedResources() (map[string]*unversioned.APIResourceList, error) {
+	return f.resources, nil
+}
+
+var _ discovery.DiscoveryInterface = &fakeDiscovery{}
+
+func TestCapabilitiesSeesNonCoreGroups(t *testing.T) {
+	fake := newFakeDiscovery()
+	caps := NewCapabilities(fake, time.Minute)
+
+	v, err := caps.APIVersions()
+	if err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	if !v.Has("v1") {
+		t.Errorf("expected v1 to be known")
+	}
+	if !v.Has("apps/v1") {
+		t.Errorf("expected apps/v1 to be known, the motivating example this checker exists for")
+	}
+	if !v.HasResource("apps", "v1", "Deployment") {
+		t.Errorf("expected apps/v1/Deployment to be known")
+	}
+	if v.HasResource("apps", "v1", "StatefulSet") {
+		t.Errorf("did not expect apps/v1/StatefulSet to be known")
+	}
+}
+
+func TestCapabilitiesCachesWithinTTL(t *testing.T) {
+	fake := newFakeDiscovery()
+	caps := NewCapabilities(fake, time.Minute)
+
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	if fake.calls != 1 {
+		t.Errorf("ServerResources called %d times, want 1 (should be cached within TTL)", fake.calls)
+	}
+}
+
+func TestCapabilitiesInvalidate(t *testing.T) {
+	fake := newFakeDiscovery()
+	caps := NewCapabilities(fake, time.Minute)
+
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	caps.Invalidate()
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	if fake.calls != 2 {
+		t.Errorf("ServerResources called %d times, want 2 (Invalidate should force a refresh)", fake.calls)
+	}
+}
+
+func TestCapabilitiesRefreshesAfterTTL(t *testing.T) {
+	fake := newFakeDiscovery()
+	caps := NewCapabilities(fake, time.Nanosecond)
+
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := caps.APIVersions(); err != nil {
+		t.Fatalf("APIVersions() error = %v", err)
+	}
+	if fake.calls != 2 {
+		t.Errorf("ServerResources called %d times, want 2 (expired TTL should refresh)", fake.calls)
+	}
+}
@@ -22,9 +22,12 @@ import (
 	"net"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/discovery"
 	"k8s.io/kubernetes/pkg/version"
 )
 
@@ -49,6 +52,7 @@ type Interface interface {
 	ComponentStatusesInterface
 	Extensions() ExtensionsInterface
 	ResourcesInterface
+	UnstructuredInterface
 }
 
 func (c *Client) ReplicationControllers(namespace string) ReplicationControllerInterface {
@@ -131,6 +135,37 @@ type APIStatus interface {
 type Client struct {
 	*RESTClient
 	*ExtensionsClient
+
+	discoveryOnce   sync.Once
+	discoveryClient *discovery.DiscoveryClient
+
+	capabilitiesOnce sync.Once
+	capabilities     *Capabilities
+
+	unstructuredOnce   sync.Once
+	unstructuredMapper discovery.RESTMapper
+}
+
+// restClientAdapter satisfies discovery.RESTInterface by delegating to the
+// embedded *RESTClient's chained Get().AbsPath(...).Do().Raw(), the same way
+// SupportedResourcesForGroupVersion below issues its raw GETs. It exists so
+// the discovery package doesn't need to import this package back (Client
+// embeds a discovery client), which would create an import cycle.
+type restClientAdapter struct {
+	*RESTClient
+}
+
+func (r restClientAdapter) Get(absPath ...string) ([]byte, error) {
+	return r.RESTClient.Get().AbsPath(absPath...).Do().Raw()
+}
+
+// Discovery returns the client used to query which API groups, versions and
+// resources this server supports.
+func (c *Client) Discovery() discovery.DiscoveryInterface {
+	c.discoveryOnce.Do(func() {
+		c.discoveryClient = discovery.NewDiscoveryClient(restClientAdapter{c.RESTClient})
+	})
+	return c.discoveryClient
 }
 
 // ServerVersion retrieves and parses the server's version.
@@ -238,6 +273,98 @@ func IsTimeout(err error) bool {
 	return false
 }
 
+// ReasonForError returns the reason carried by err's Status if err can be
+// converted to an APIStatus, or unversioned.StatusReasonUnknown otherwise.
+func ReasonForError(err error) unversioned.StatusReason {
+	if status, ok := err.(APIStatus); ok {
+		return status.Status().Reason
+	}
+	return unversioned.StatusReasonUnknown
+}
+
+// IsNotFound returns true if the specified error was created by NewNotFound.
+func IsNotFound(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonNotFound
+}
+
+// IsAlreadyExists determines if the err is an error which indicates that a
+// specified resource already exists.
+func IsAlreadyExists(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonAlreadyExists
+}
+
+// IsConflict determines if the err is an error which indicates the request
+// could not complete due to a conflict.
+func IsConflict(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonConflict
+}
+
+// IsForbidden determines if err is an error which indicates that the
+// request is forbidden and cannot be completed as requested.
+func IsForbidden(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonForbidden
+}
+
+// IsUnauthorized determines if err is an error which indicates that the
+// request is unauthorized and requires authentication by the user.
+func IsUnauthorized(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonUnauthorized
+}
+
+// IsServerTimeout determines if err is an error which indicates that the
+// request needs to be retried by the client.
+func IsServerTimeout(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonServerTimeout
+}
+
+// IsTooManyRequests determines if err is an error which indicates that
+// there are too many requests that the server cannot handle.
+func IsTooManyRequests(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonTooManyRequests
+}
+
+// IsInternalError determines if err is an error which indicates an internal
+// server error.
+func IsInternalError(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonInternalError
+}
+
+// IsInvalid determines if the err is an error which indicates the provided
+// resource is not valid.
+func IsInvalid(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonInvalid
+}
+
+// IsGone is true if the error indicates the requested resource is no longer
+// available.
+func IsGone(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonGone
+}
+
+// IsServiceUnavailable is true if the error indicates the underlying
+// service is no longer available.
+func IsServiceUnavailable(err error) bool {
+	return ReasonForError(err) == unversioned.StatusReasonServiceUnavailable
+}
+
+// SuggestsClientDelay returns true if this error suggests a client delay as
+// well as the duration to delay, or false if the error does not imply a
+// wait. It covers the 429 and 503 status codes a retry-loop should back off
+// on.
+func SuggestsClientDelay(err error) (time.Duration, bool) {
+	status, ok := err.(APIStatus)
+	if !ok {
+		return 0, false
+	}
+	switch status.Status().Reason {
+	case unversioned.StatusReasonServerTimeout, unversioned.StatusReasonTooManyRequests, unversioned.StatusReasonServiceUnavailable:
+		if details := status.Status().Details; details != nil && details.RetryAfterSeconds > 0 {
+			return time.Duration(details.RetryAfterSeconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
 func (c *Client) Extensions() ExtensionsInterface {
 	return c.ExtensionsClient
 }
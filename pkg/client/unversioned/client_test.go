@@ -0,0 +1,106 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+type fakeStatusError struct {
+	status unversioned.Status
+}
+
+func (e *fakeStatusError) Error() string              { return string(e.status.Reason) }
+func (e *fakeStatusError) Status() unversioned.Status { return e.status }
+
+func TestSuggestsClientDelay(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantDelay  time.Duration
+		wantDelays bool
+	}{
+		{
+			name:       "not an APIStatus",
+			err:        fmt.Errorf("boom"),
+			wantDelays: false,
+		},
+		{
+			name: "server timeout with retry-after",
+			err: &fakeStatusError{status: unversioned.Status{
+				Reason:  unversioned.StatusReasonServerTimeout,
+				Details: &unversioned.StatusDetails{RetryAfterSeconds: 5},
+			}},
+			wantDelay:  5 * time.Second,
+			wantDelays: true,
+		},
+		{
+			name: "too many requests with retry-after",
+			err: &fakeStatusError{status: unversioned.Status{
+				Reason:  unversioned.StatusReasonTooManyRequests,
+				Details: &unversioned.StatusDetails{RetryAfterSeconds: 2},
+			}},
+			wantDelay:  2 * time.Second,
+			wantDelays: true,
+		},
+		{
+			name: "service unavailable with retry-after",
+			err: &fakeStatusError{status: unversioned.Status{
+				Reason:  unversioned.StatusReasonServiceUnavailable,
+				Details: &unversioned.StatusDetails{RetryAfterSeconds: 3},
+			}},
+			wantDelay:  3 * time.Second,
+			wantDelays: true,
+		},
+		{
+			name: "not found never suggests delay",
+			err: &fakeStatusError{status: unversioned.Status{
+				Reason: unversioned.StatusReasonNotFound,
+			}},
+			wantDelays: false,
+		},
+	}
+
+	for _, c := range cases {
+		delay, ok := SuggestsClientDelay(c.err)
+		if ok != c.wantDelays {
+			t.Errorf("%s: SuggestsClientDelay() ok = %v, want %v", c.name, ok, c.wantDelays)
+		}
+		if ok && delay != c.wantDelay {
+			t.Errorf("%s: SuggestsClientDelay() delay = %v, want %v", c.name, delay, c.wantDelay)
+		}
+	}
+}
+
+func TestIsHelpers(t *testing.T) {
+	notFound := &fakeStatusError{status: unversioned.Status{Reason: unversioned.StatusReasonNotFound}}
+	if !IsNotFound(notFound) {
+		t.Errorf("IsNotFound() = false, want true")
+	}
+	if IsConflict(notFound) {
+		t.Errorf("IsConflict() = true, want false")
+	}
+
+	serviceUnavailable := &fakeStatusError{status: unversioned.Status{Reason: unversioned.StatusReasonServiceUnavailable}}
+	if !IsServiceUnavailable(serviceUnavailable) {
+		t.Errorf("IsServiceUnavailable() = false, want true")
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package discovery provides ways to discover the resources supported by an
+// API server, and to translate between the short-hand names consumers use
+// ("pods", "deploy/foo") and the fully qualified group/version/kind the
+// server actually speaks.
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// maxConcurrentRequests bounds how many group/versions are queried in
+// parallel when aggregating discovery documents. A bare sync.WaitGroup over
+// every group/version risks opening hundreds of sockets against a server
+// with many registered API groups.
+const maxConcurrentRequests = 10
+
+// RESTInterface is the minimal surface DiscoveryClient needs in order to
+// issue raw GETs against the API server's discovery endpoints. The
+// unversioned package satisfies it with a thin adapter around its
+// *RESTClient; it is declared separately here
+// (rather than importing the unversioned package directly) so that the
+// unversioned package can embed a DiscoveryClient without creating an
+// import cycle.
+type RESTInterface interface {
+	Get(absPath ...string) ([]byte, error)
+}
+
+// DiscoveryInterface holds the methods that discover server-supported API
+// groups, versions and resources.
+type DiscoveryInterface interface {
+	ServerGroups() (*unversioned.APIGroupList, error)
+	ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error)
+	ServerResources() (map[string]*unversioned.APIResourceList, error)
+	ServerPreferredResources() (map[string]*unversioned.APIResourceList, error)
+	ServerPreferredNamespacedResources() (map[string]*unversioned.APIResourceList, error)
+}
+
+// DiscoveryClient implements DiscoveryInterface on top of a plain
+// RESTInterface, the same way the typed clients in pkg/client/unversioned
+// build on top of *RESTClient.
+type DiscoveryClient struct {
+	restClient RESTInterface
+}
+
+// NewDiscoveryClient returns a new DiscoveryClient for the given low-level
+// REST client.
+func NewDiscoveryClient(c RESTInterface) *DiscoveryClient {
+	return &DiscoveryClient{restClient: c}
+}
+
+var _ DiscoveryInterface = &DiscoveryClient{}
+
+// ServerGroups returns the supported groups, with information like supported
+// versions and the preferred version.
+func (c *DiscoveryClient) ServerGroups() (*unversioned.APIGroupList, error) {
+	body, err := c.restClient.Get("/apis")
+	if err != nil {
+		return nil, err
+	}
+	groupList := &unversioned.APIGroupList{}
+	if err := json.Unmarshal(body, groupList); err != nil {
+		return nil, err
+	}
+
+	// The legacy "v1" group lives under /api, not /apis, and is not listed
+	// alongside the other groups, so splice it in as an unnamed group.
+	v1Body, err := c.restClient.Get("/api")
+	if err == nil {
+		v1 := unversioned.APIVersions{}
+		if err := json.Unmarshal(v1Body, &v1); err == nil && len(v1.Versions) > 0 {
+			groupList.Groups = append(groupList.Groups, unversioned.APIGroup{
+				Versions: []unversioned.GroupVersionForDiscovery{
+					{GroupVersion: v1.Versions[0], Version: v1.Versions[0]},
+				},
+				PreferredVersion: unversioned.GroupVersionForDiscovery{
+					GroupVersion: v1.Versions[0], Version: v1.Versions[0],
+				},
+			})
+		}
+	}
+	return groupList, nil
+}
+
+// ServerResourcesForGroupVersion returns the supported resources for a group
+// and version.
+func (c *DiscoveryClient) ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error) {
+	prefix := "/apis"
+	if groupVersion == "v1" {
+		prefix = "/api"
+	}
+	body, err := c.restClient.Get(prefix, groupVersion)
+	if err != nil {
+		return nil, err
+	}
+	resources := &unversioned.APIResourceList{}
+	if err := json.Unmarshal(body, resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// ServerResources returns the supported resources for every group and
+// version known to the server, fetched concurrently with bounded
+// parallelism. Partial failures (e.g. a single group/version that 404s
+// because a CRD was deleted mid-discovery) are aggregated and returned
+// alongside whatever results did succeed, mirroring how client-go's
+// errors.Aggregate is used elsewhere in this codebase.
+func (c *DiscoveryClient) ServerResources() (map[string]*unversioned.APIResourceList, error) {
+	groups, err := c.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	groupVersions := ExtractGroupVersions(groups)
+	return c.serverResourcesForGroupVersions(groupVersions)
+}
+
+func (c *DiscoveryClient) serverResourcesForGroupVersions(groupVersions []string) (map[string]*unversioned.APIResourceList, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sem      = make(chan struct{}, maxConcurrentRequests)
+		result   = map[string]*unversioned.APIResourceList{}
+		errs     []error
+	)
+
+	for _, gv := range groupVersions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(gv string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resources, err := c.ServerResourcesForGroupVersion(gv)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("unable to retrieve the complete list of server resources for %q: %v", gv, err))
+				return
+			}
+			result[gv] = resources
+		}(gv)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return result, newAggregate(errs)
+	}
+	return result, nil
+}
+
+// ServerPreferredResources returns the supported resources, limited to the
+// preferred version of each group.
+func (c *DiscoveryClient) ServerPreferredResources() (map[string]*unversioned.APIResourceList, error) {
+	groups, err := c.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	return c.serverResourcesForGroupVersions(preferredGroupVersions(groups))
+}
+
+// ServerPreferredNamespacedResources returns the supported namespaced
+// resources, limited to the preferred version of each group.
+func (c *DiscoveryClient) ServerPreferredNamespacedResources() (map[string]*unversioned.APIResourceList, error) {
+	preferred, err := c.ServerPreferredResources()
+	if err != nil {
+		return nil, err
+	}
+	namespaced := map[string]*unversioned.APIResourceList{}
+	for gv, list := range preferred {
+		filtered := &unversioned.APIResourceList{GroupVersion: list.GroupVersion}
+		for _, resource := range list.APIResources {
+			if resource.Namespaced {
+				filtered.APIResources = append(filtered.APIResources, resource)
+			}
+		}
+		namespaced[gv] = filtered
+	}
+	return namespaced, nil
+}
+
+// ExtractGroupVersions returns the list of "group/version" (or just
+// "version" for the legacy v1 group) strings advertised by an APIGroupList.
+func ExtractGroupVersions(groups *unversioned.APIGroupList) []string {
+	result := []string{}
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			result = append(result, version.GroupVersion)
+		}
+	}
+	return result
+}
+
+func preferredGroupVersions(groups *unversioned.APIGroupList) []string {
+	result := make([]string, 0, len(groups.Groups))
+	for _, group := range groups.Groups {
+		result = append(result, group.PreferredVersion.GroupVersion)
+	}
+	return result
+}
+
+// aggregate is a minimal error.Aggregate implementation so this package does
+// not have to reach into pkg/util/errors for a single helper.
+type aggregate []error
+
+func newAggregate(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return aggregate(errs)
+}
+
+func (agg aggregate) Error() string {
+	if len(agg) == 1 {
+		return agg[0].Error()
+	}
+	msg := fmt.Sprintf("%d errors occurred:", len(agg))
+	for _, err := range agg {
+		msg += "\n* " + err.Error()
+	}
+	return msg
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+func TestAggregateError(t *testing.T) {
+	if err := newAggregate(nil); err != nil {
+		t.Errorf("newAggregate(nil) = %v, want nil", err)
+	}
+
+	single := newAggregate([]error{errors.New("boom")})
+	if single.Error() != "boom" {
+		t.Errorf("single error message = %q, want %q", single.Error(), "boom")
+	}
+
+	multi := newAggregate([]error{errors.New("a"), errors.New("b")})
+	want := "2 errors occurred:\n* a\n* b"
+	if multi.Error() != want {
+		t.Errorf("multi error message = %q, want %q", multi.Error(), want)
+	}
+}
+
+// fakeRESTInterface serves canned discovery documents over Get and records
+// how many requests are in flight at once, so tests can assert
+// serverResourcesForGroupVersions actually bounds its parallelism rather
+// than opening one goroutine per group/version.
+type fakeRESTInterface struct {
+	groups    *unversioned.APIGroupList
+	core      *unversioned.APIVersions
+	resources map[string]*unversioned.APIResourceList
+	failing   map[string]bool
+
+	mu       sync.Mutex
+	calls    map[string]int
+	inFlight int
+	peak     int
+}
+
+var _ RESTInterface = &fakeRESTInterface{}
+
+func (f *fakeRESTInterface) Get(absPath ...string) ([]byte, error) {
+	switch {
+	case len(absPath) == 1 && absPath[0] == "/apis":
+		return json.Marshal(f.groups)
+	case len(absPath) == 1 && absPath[0] == "/api":
+		if f.core == nil {
+			return nil, errors.New("no legacy core group registered")
+		}
+		return json.Marshal(f.core)
+	default:
+		gv := absPath[len(absPath)-1]
+
+		f.mu.Lock()
+		f.calls[gv]++
+		f.inFlight++
+		if f.inFlight > f.peak {
+			f.peak = f.inFlight
+		}
+		f.mu.Unlock()
+
+		// Yield so other goroutines queued behind the semaphore get a
+		// chance to start before this one finishes; otherwise the
+		// scheduler could serialize everything and peak would never
+		// exceed 1 regardless of whether the real code is concurrent.
+		runtime.Gosched()
+
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+
+		if f.failing[gv] {
+			return nil, fmt.Errorf("simulated failure for %q", gv)
+		}
+		list, ok := f.resources[gv]
+		if !ok {
+			return nil, fmt.Errorf("no resources registered for %q", gv)
+		}
+		return json.Marshal(list)
+	}
+}
+
+// manyGroupVersions builds n distinct single-version groups, each with one
+// resource, so tests can drive enough concurrent group/version fetches to
+// actually exercise the maxConcurrentRequests cap.
+func manyGroupVersions(n int) (*unversioned.APIGroupList, map[string]*unversioned.APIResourceList) {
+	groups := &unversioned.APIGroupList{}
+	resources := map[string]*unversioned.APIResourceList{}
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("group%d", i)
+		gv := name + "/v1"
+		groups.Groups = append(groups.Groups, unversioned.APIGroup{
+			Name:             name,
+			Versions:         []unversioned.GroupVersionForDiscovery{{GroupVersion: gv, Version: "v1"}},
+			PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: gv, Version: "v1"},
+		})
+		resources[gv] = &unversioned.APIResourceList{
+			GroupVersion: gv,
+			APIResources: []unversioned.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}},
+		}
+	}
+	return groups, resources
+}
+
+func TestServerResourcesRespectsMaxConcurrentRequests(t *testing.T) {
+	groups, resources := manyGroupVersions(maxConcurrentRequests * 3)
+	fake := &fakeRESTInterface{groups: groups, resources: resources, calls: map[string]int{}}
+	c := NewDiscoveryClient(fake)
+
+	got, err := c.ServerResources()
+	if err != nil {
+		t.Fatalf("ServerResources() error = %v", err)
+	}
+	if len(got) != len(resources) {
+		t.Errorf("ServerResources() returned %d group/versions, want %d", len(got), len(resources))
+	}
+
+	fake.mu.Lock()
+	peak := fake.peak
+	fake.mu.Unlock()
+	if peak > maxConcurrentRequests {
+		t.Errorf("observed %d concurrent requests, want at most maxConcurrentRequests (%d)", peak, maxConcurrentRequests)
+	}
+}
+
+func TestServerResourcesAggregatesPartialFailures(t *testing.T) {
+	groups, resources := manyGroupVersions(3)
+	fake := &fakeRESTInterface{
+		groups:    groups,
+		resources: resources,
+		calls:     map[string]int{},
+		failing:   map[string]bool{"group1/v1": true},
+	}
+	c := NewDiscoveryClient(fake)
+
+	got, err := c.ServerResources()
+	if err == nil {
+		t.Fatalf("ServerResources() error = nil, want an aggregate error for the failing group/version")
+	}
+	if !strings.Contains(err.Error(), "group1/v1") {
+		t.Errorf("ServerResources() error = %q, want it to mention the failing group/version", err.Error())
+	}
+	if len(got) != 2 {
+		t.Errorf("ServerResources() returned %d group/versions despite one failure, want the other 2 to still come back", len(got))
+	}
+}
+
+func TestServerGroupsSplicesLegacyCoreGroup(t *testing.T) {
+	fake := &fakeRESTInterface{
+		groups: &unversioned.APIGroupList{},
+		core:   &unversioned.APIVersions{Versions: []string{"v1"}},
+		calls:  map[string]int{},
+	}
+	c := NewDiscoveryClient(fake)
+
+	groups, err := c.ServerGroups()
+	if err != nil {
+		t.Fatalf("ServerGroups() error = %v", err)
+	}
+	if len(groups.Groups) != 1 {
+		t.Fatalf("ServerGroups() returned %d groups, want 1 (the spliced-in legacy core group)", len(groups.Groups))
+	}
+	if got := groups.Groups[0].PreferredVersion.GroupVersion; got != "v1" {
+		t.Errorf("legacy core group PreferredVersion.GroupVersion = %q, want \"v1\"", got)
+	}
+}
+
+func TestServerPreferredResourcesUsesOnlyPreferredVersion(t *testing.T) {
+	groups := &unversioned.APIGroupList{
+		Groups: []unversioned.APIGroup{
+			{
+				Name: "apps",
+				Versions: []unversioned.GroupVersionForDiscovery{
+					{GroupVersion: "apps/v1beta1", Version: "v1beta1"},
+					{GroupVersion: "apps/v1", Version: "v1"},
+				},
+				PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "apps/v1", Version: "v1"},
+			},
+		},
+	}
+	resources := map[string]*unversioned.APIResourceList{
+		"apps/v1beta1": {
+			GroupVersion: "apps/v1beta1",
+			APIResources: []unversioned.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+		},
+		"apps/v1": {
+			GroupVersion: "apps/v1",
+			APIResources: []unversioned.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "controllerrevisions", Kind: "ControllerRevision", Namespaced: false},
+			},
+		},
+	}
+	fake := &fakeRESTInterface{groups: groups, resources: resources, calls: map[string]int{}}
+	c := NewDiscoveryClient(fake)
+
+	preferred, err := c.ServerPreferredResources()
+	if err != nil {
+		t.Fatalf("ServerPreferredResources() error = %v", err)
+	}
+	if _, ok := preferred["apps/v1beta1"]; ok {
+		t.Errorf("ServerPreferredResources() included the non-preferred apps/v1beta1")
+	}
+	if _, ok := preferred["apps/v1"]; !ok {
+		t.Fatalf("ServerPreferredResources() missing the preferred apps/v1")
+	}
+
+	namespaced, err := c.ServerPreferredNamespacedResources()
+	if err != nil {
+		t.Fatalf("ServerPreferredNamespacedResources() error = %v", err)
+	}
+	got := namespaced["apps/v1"].APIResources
+	if len(got) != 1 || got[0].Name != "deployments" {
+		t.Errorf("ServerPreferredNamespacedResources()[\"apps/v1\"] = %v, want only the namespaced \"deployments\"", got)
+	}
+}
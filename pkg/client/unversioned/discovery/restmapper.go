@@ -0,0 +1,320 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// RESTMapper translates between the short-hand names users and callers deal
+// in (Kind, Resource) and the GroupVersionResource a REST client needs to
+// build a request URL, using the live discovery document rather than a
+// compiled-in scheme.
+type RESTMapper interface {
+	// KindFor returns the preferred GroupVersionKind for a resource, given
+	// either its plural, singular or short name.
+	KindFor(resource string) (unversioned.GroupVersionKind, error)
+	// ResourceFor returns the preferred GroupVersionResource for a kind.
+	ResourceFor(kind unversioned.GroupVersionKind) (unversioned.GroupVersionResource, error)
+	// ResourceSingularizer returns the singular form of a resource name.
+	ResourceSingularizer(resource string) string
+	// Namespaced returns true if the resource is namespace-scoped.
+	Namespaced(resource unversioned.GroupVersionResource) (bool, error)
+}
+
+// apiResourceMeta is indexed by the exact GroupVersionResource a caller
+// already has in hand, so it's kept for every resource discovery reports,
+// subresources included.
+type apiResourceMeta struct {
+	kind       unversioned.GroupVersionKind
+	namespaced bool
+}
+
+// resourceInfo is the candidate type KindFor/ResourceFor search over: only
+// primary resources (no "pods/status"-style subresources) are eligible,
+// since a subresource always reports its parent's Kind and must never win
+// a name/kind lookup.
+type resourceInfo struct {
+	gvr  unversioned.GroupVersionResource
+	kind unversioned.GroupVersionKind
+}
+
+// groupPriority lists well-known groups in the order KindFor/ResourceFor
+// should prefer them when more than one group serves the same resource or
+// kind name (e.g. "deployments"/"Deployment" in both "apps" and
+// "extensions"). Groups not listed here sort after all listed groups,
+// alphabetically among themselves, so resolution stays deterministic even
+// for unlisted CRDs rather than depending on map iteration order.
+var groupPriority = []string{
+	"", // legacy core group
+	"apps",
+	"extensions",
+	"batch",
+	"autoscaling",
+	"policy",
+	"networking.k8s.io",
+	"rbac.authorization.k8s.io",
+	"storage.k8s.io",
+	"apiextensions.k8s.io",
+	"events.k8s.io",
+}
+
+func groupPriorityIndex(group string) int {
+	for i, g := range groupPriority {
+		if g == group {
+			return i
+		}
+	}
+	return len(groupPriority)
+}
+
+// DeferredDiscoveryRESTMapper is a RESTMapper backed by a DiscoveryInterface.
+// It builds its kind/resource index lazily on first use and can be told to
+// throw that index away with Reset when the set of registered CRDs changes.
+type DeferredDiscoveryRESTMapper struct {
+	discoveryClient DiscoveryInterface
+
+	loaded             bool
+	byResource         map[unversioned.GroupVersionResource]apiResourceMeta
+	primary            []resourceInfo
+	preferredVersionOf map[string]string
+}
+
+// NewDeferredDiscoveryRESTMapper returns a RESTMapper that consults the
+// given discovery client the first time a lookup is requested.
+func NewDeferredDiscoveryRESTMapper(discoveryClient DiscoveryInterface) *DeferredDiscoveryRESTMapper {
+	return &DeferredDiscoveryRESTMapper{discoveryClient: discoveryClient}
+}
+
+// Reset discards the cached kind/resource index, forcing the next lookup to
+// re-query discovery. Call this after installing a new CRD.
+func (m *DeferredDiscoveryRESTMapper) Reset() {
+	m.loaded = false
+	m.byResource = nil
+	m.primary = nil
+	m.preferredVersionOf = nil
+}
+
+func (m *DeferredDiscoveryRESTMapper) load() error {
+	if m.loaded {
+		return nil
+	}
+	groups, err := m.discoveryClient.ServerGroups()
+	if err != nil {
+		return err
+	}
+	preferredVersionOf := map[string]string{}
+	for _, group := range groups.Groups {
+		preferredVersionOf[group.Name] = group.PreferredVersion.Version
+	}
+
+	resourcesByGroupVersion, err := m.discoveryClient.ServerResources()
+	if err != nil {
+		return err
+	}
+
+	byResource := map[unversioned.GroupVersionResource]apiResourceMeta{}
+	var primary []resourceInfo
+	for groupVersion, list := range resourcesByGroupVersion {
+		gv, err := unversioned.ParseGroupVersion(groupVersion)
+		if err != nil {
+			return err
+		}
+		for _, resource := range list.APIResources {
+			gvr := gv.WithResource(resource.Name)
+			gvk := gv.WithKind(resource.Kind)
+			byResource[gvr] = apiResourceMeta{kind: gvk, namespaced: resource.Namespaced}
+
+			// Subresources (e.g. "pods/status", "deployments/scale") report
+			// the same Kind as their parent resource; they stay addressable
+			// via byResource for callers that already have the exact
+			// GroupVersionResource; but they must never be candidates for
+			// KindFor/ResourceFor, or a Kind could resolve to a subresource
+			// path instead of the primary resource.
+			if strings.Contains(resource.Name, "/") {
+				continue
+			}
+			primary = append(primary, resourceInfo{gvr: gvr, kind: gvk})
+		}
+	}
+
+	m.byResource = byResource
+	m.primary = primary
+	m.preferredVersionOf = preferredVersionOf
+	m.loaded = true
+	return nil
+}
+
+// pickPreferred deterministically resolves multiple candidates for the same
+// resource or kind name to a single result: it first narrows to each
+// candidate's own group's preferred version (so e.g. "apps/v1beta1" never
+// wins over "apps/v1" once apps/v1 is what the group prefers), then breaks
+// any remaining tie across groups using groupPriority, falling back to
+// alphabetical group, then version, then resource order so the result never
+// depends on slice iteration order.
+func (m *DeferredDiscoveryRESTMapper) pickPreferred(candidates []resourceInfo) (resourceInfo, bool) {
+	if len(candidates) == 0 {
+		return resourceInfo{}, false
+	}
+
+	var preferred []resourceInfo
+	for _, c := range candidates {
+		if c.gvr.Version == m.preferredVersionOf[c.gvr.Group] {
+			preferred = append(preferred, c)
+		}
+	}
+	if len(preferred) > 0 {
+		candidates = preferred
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		pi, pj := groupPriorityIndex(candidates[i].gvr.Group), groupPriorityIndex(candidates[j].gvr.Group)
+		if pi != pj {
+			return pi < pj
+		}
+		if candidates[i].gvr.Group != candidates[j].gvr.Group {
+			return candidates[i].gvr.Group < candidates[j].gvr.Group
+		}
+		if candidates[i].gvr.Version != candidates[j].gvr.Version {
+			return candidates[i].gvr.Version < candidates[j].gvr.Version
+		}
+		return candidates[i].gvr.Resource < candidates[j].gvr.Resource
+	})
+	return candidates[0], true
+}
+
+// shortForms maps the kubectl-style short names in common use to the
+// resource name the server actually advertises through discovery. This
+// mirrors the short-name table kubectl's own RESTMapper ships with; it is
+// not derived from the discovery document because the server doesn't
+// advertise short names at all.
+var shortForms = map[string]string{
+	"po":     "pods",
+	"svc":    "services",
+	"rc":     "replicationcontrollers",
+	"ns":     "namespaces",
+	"no":     "nodes",
+	"cm":     "configmaps",
+	"sa":     "serviceaccounts",
+	"pv":     "persistentvolumes",
+	"pvc":    "persistentvolumeclaims",
+	"ep":     "endpoints",
+	"limits": "limitranges",
+	"quota":  "resourcequotas",
+	"deploy": "deployments",
+	"ds":     "daemonsets",
+	"rs":     "replicasets",
+	"sts":    "statefulsets",
+	"cs":     "componentstatuses",
+}
+
+// KindFor implements RESTMapper.
+func (m *DeferredDiscoveryRESTMapper) KindFor(resource string) (unversioned.GroupVersionKind, error) {
+	if err := m.load(); err != nil {
+		return unversioned.GroupVersionKind{}, err
+	}
+	lower := strings.ToLower(resource)
+	if plural, ok := shortForms[lower]; ok {
+		lower = plural
+	}
+	singular := m.ResourceSingularizer(lower)
+
+	var candidates []resourceInfo
+	for _, info := range m.primary {
+		name := strings.ToLower(info.gvr.Resource)
+		if name == lower || m.ResourceSingularizer(name) == singular {
+			candidates = append(candidates, info)
+		}
+	}
+	best, ok := m.pickPreferred(candidates)
+	if !ok {
+		return unversioned.GroupVersionKind{}, fmt.Errorf("no matches for resource %q", resource)
+	}
+	return best.kind, nil
+}
+
+// ResourceFor implements RESTMapper. kind.Group and kind.Version may be left
+// empty to mean "any group"/"the group's preferred version" respectively;
+// pickPreferred resolves the remaining ambiguity deterministically.
+func (m *DeferredDiscoveryRESTMapper) ResourceFor(kind unversioned.GroupVersionKind) (unversioned.GroupVersionResource, error) {
+	if err := m.load(); err != nil {
+		return unversioned.GroupVersionResource{}, err
+	}
+	var candidates []resourceInfo
+	for _, info := range m.primary {
+		if info.kind.Kind != kind.Kind {
+			continue
+		}
+		if kind.Group != "" && info.kind.Group != kind.Group {
+			continue
+		}
+		if kind.Version != "" && info.kind.Version != kind.Version {
+			continue
+		}
+		candidates = append(candidates, info)
+	}
+	best, ok := m.pickPreferred(candidates)
+	if !ok {
+		return unversioned.GroupVersionResource{}, fmt.Errorf("no resource found for kind %v", kind)
+	}
+	return best.gvr, nil
+}
+
+// Namespaced implements RESTMapper.
+func (m *DeferredDiscoveryRESTMapper) Namespaced(resource unversioned.GroupVersionResource) (bool, error) {
+	if err := m.load(); err != nil {
+		return false, err
+	}
+	meta, ok := m.byResource[resource]
+	if !ok {
+		return false, fmt.Errorf("no matches for %v", resource)
+	}
+	return meta.namespaced, nil
+}
+
+// irregularPlurals holds resource names whose singular form the generic
+// suffix rules in ResourceSingularizer get wrong: "endpoints" has no
+// singular form at all, and "componentstatuses" pluralizes "componentstatus"
+// with a "-uses" ending the "sses" rule below doesn't catch.
+var irregularPlurals = map[string]string{
+	"endpoints":         "endpoints",
+	"componentstatuses": "componentstatus",
+}
+
+// ResourceSingularizer implements RESTMapper using the same simple English
+// pluralization rules the server itself uses when advertising resource
+// names (e.g. "pods" -> "pod", "endpoints" -> "endpoints", "classes" ->
+// "class"), with a small table of irregular names that don't fit those
+// rules.
+func (m *DeferredDiscoveryRESTMapper) ResourceSingularizer(resource string) string {
+	if singular, ok := irregularPlurals[resource]; ok {
+		return singular
+	}
+	switch {
+	case strings.HasSuffix(resource, "ies"):
+		return resource[:len(resource)-3] + "y"
+	case strings.HasSuffix(resource, "sses"):
+		return resource[:len(resource)-2]
+	case strings.HasSuffix(resource, "s") && !strings.HasSuffix(resource, "ss"):
+		return resource[:len(resource)-1]
+	}
+	return resource
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discovery
+
+import (
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+func TestResourceSingularizer(t *testing.T) {
+	mapper := &DeferredDiscoveryRESTMapper{}
+	cases := []struct {
+		plural   string
+		singular string
+	}{
+		{"pods", "pod"},
+		{"classes", "class"},
+		{"ingresses", "ingress"},
+		{"policies", "policy"},
+		{"endpoints", "endpoints"},
+		{"componentstatuses", "componentstatus"},
+	}
+	for _, c := range cases {
+		if got := mapper.ResourceSingularizer(c.plural); got != c.singular {
+			t.Errorf("ResourceSingularizer(%q) = %q, want %q", c.plural, got, c.singular)
+		}
+	}
+}
+
+// fakeRESTMapperDiscovery serves a fixed discovery document with a
+// deliberately ambiguous "deployments"/"Deployment" registered in both the
+// "apps" and "extensions" groups, to exercise preferred-group resolution.
+type fakeRESTMapperDiscovery struct {
+	groups    *unversioned.APIGroupList
+	resources map[string]*unversioned.APIResourceList
+}
+
+func (f *fakeRESTMapperDiscovery) ServerGroups() (*unversioned.APIGroupList, error) {
+	return f.groups, nil
+}
+
+func (f *fakeRESTMapperDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error) {
+	return f.resources[groupVersion], nil
+}
+
+func (f *fakeRESTMapperDiscovery) ServerResources() (map[string]*unversioned.APIResourceList, error) {
+	return f.resources, nil
+}
+
+func (f *fakeRESTMapperDiscovery) ServerPreferredResources() (map[string]*unversioned.APIResourceList, error) {
+	return f.resources, nil
+}
+
+func (f *fakeRESTMapperDiscovery) ServerPreferredNamespacedResources() (map[string]*unversioned.APIResourceList, error) {
+	return f.resources, nil
+}
+
+func newTestMapper() *DeferredDiscoveryRESTMapper {
+	groups := &unversioned.APIGroupList{
+		Groups: []unversioned.APIGroup{
+			{
+				Name:             "apps",
+				PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "apps/v1", Version: "v1"},
+			},
+			{
+				Name:             "extensions",
+				PreferredVersion: unversioned.GroupVersionForDiscovery{GroupVersion: "extensions/v1beta1", Version: "v1beta1"},
+			},
+		},
+	}
+	resources := map[string]*unversioned.APIResourceList{
+		"v1": {
+			GroupVersion: "v1",
+			APIResources: []unversioned.APIResource{
+				{Name: "pods", Kind: "Pod", Namespaced: true},
+				{Name: "pods/status", Kind: "Pod", Namespaced: true},
+			},
+		},
+		"apps/v1": {
+			GroupVersion: "apps/v1",
+			APIResources: []unversioned.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+			},
+		},
+		"extensions/v1beta1": {
+			GroupVersion: "extensions/v1beta1",
+			APIResources: []unversioned.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+				{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+			},
+		},
+	}
+	return NewDeferredDiscoveryRESTMapper(&fakeRESTMapperDiscovery{groups: groups, resources: resources})
+}
+
+func TestKindForPrefersHigherPriorityGroup(t *testing.T) {
+	mapper := newTestMapper()
+	// "deployments" exists in both apps/v1 and extensions/v1beta1, each its
+	// group's own preferred version; apps must win deterministically.
+	for i := 0; i < 20; i++ {
+		gvk, err := mapper.KindFor("deployments")
+		if err != nil {
+			t.Fatalf("KindFor() error = %v", err)
+		}
+		if gvk.Group != "apps" || gvk.Version != "v1" || gvk.Kind != "Deployment" {
+			t.Fatalf("KindFor(\"deployments\") = %v, want apps/v1 Deployment", gvk)
+		}
+	}
+}
+
+func TestKindForShortNameAndSingular(t *testing.T) {
+	mapper := newTestMapper()
+	for _, name := range []string{"pod", "pods", "po"} {
+		gvk, err := mapper.KindFor(name)
+		if err != nil {
+			t.Fatalf("KindFor(%q) error = %v", name, err)
+		}
+		if gvk.Group != "" || gvk.Version != "v1" || gvk.Kind != "Pod" {
+			t.Errorf("KindFor(%q) = %v, want core/v1 Pod", name, gvk)
+		}
+	}
+}
+
+func TestKindForUnambiguousResource(t *testing.T) {
+	mapper := newTestMapper()
+	gvk, err := mapper.KindFor("ingresses")
+	if err != nil {
+		t.Fatalf("KindFor() error = %v", err)
+	}
+	if gvk.Group != "extensions" || gvk.Kind != "Ingress" {
+		t.Errorf("KindFor(\"ingresses\") = %v, want extensions Ingress", gvk)
+	}
+}
+
+func TestKindForSubresourceNeverMatches(t *testing.T) {
+	mapper := newTestMapper()
+	if _, err := mapper.KindFor("pods/status"); err == nil {
+		t.Errorf("KindFor(\"pods/status\") succeeded, want an error since subresources aren't primary resources")
+	}
+}
+
+func TestResourceForExactGVK(t *testing.T) {
+	mapper := newTestMapper()
+	gvr, err := mapper.ResourceFor(unversioned.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Deployment"})
+	if err != nil {
+		t.Fatalf("ResourceFor() error = %v", err)
+	}
+	want := unversioned.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "deployments"}
+	if gvr != want {
+		t.Errorf("ResourceFor() = %v, want %v", gvr, want)
+	}
+}
+
+func TestResourceForAmbiguousKindPrefersHigherPriorityGroup(t *testing.T) {
+	mapper := newTestMapper()
+	gvr, err := mapper.ResourceFor(unversioned.GroupVersionKind{Kind: "Deployment"})
+	if err != nil {
+		t.Fatalf("ResourceFor() error = %v", err)
+	}
+	if gvr.Group != "apps" || gvr.Version != "v1" {
+		t.Errorf("ResourceFor(Kind=Deployment) = %v, want apps/v1", gvr)
+	}
+}
+
+func TestNamespaced(t *testing.T) {
+	mapper := newTestMapper()
+	namespaced, err := mapper.Namespaced(unversioned.GroupVersionResource{Version: "v1", Resource: "pods"})
+	if err != nil {
+		t.Fatalf("Namespaced() error = %v", err)
+	}
+	if !namespaced {
+		t.Errorf("Namespaced(v1/pods) = false, want true")
+	}
+
+	if _, err := mapper.Namespaced(unversioned.GroupVersionResource{Version: "v1", Resource: "nonexistent"}); err == nil {
+		t.Errorf("Namespaced() for an unknown resource succeeded, want an error")
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// ErrPrerequisitesFailed is the sentinel wrapped by every PrerequisitesError,
+// so callers that don't care about the details can do a plain comparison
+// while callers that do can type-assert to *PrerequisitesError.
+var ErrPrerequisitesFailed = errors.New("prerequisites not satisfied")
+
+// PrerequisitesError reports why CheckPrerequisites failed: either the
+// resource itself isn't registered on the server, or the caller is missing
+// one or more of the required RBAC verbs, broken down per namespace.
+type PrerequisitesError struct {
+	// MissingResource is set when groupVersion/resource isn't served at all.
+	MissingResource string
+	// MissingVerbsByNamespace maps a namespace (or "" for cluster scope) to
+	// the "resource:verb" pairs the caller isn't permitted to use there.
+	MissingVerbsByNamespace map[string][]string
+}
+
+func (e *PrerequisitesError) Error() string {
+	if e.MissingResource != "" {
+		return fmt.Sprintf("prerequisites not satisfied: resource %q is not registered on the server", e.MissingResource)
+	}
+	namespaces := make([]string, 0, len(e.MissingVerbsByNamespace))
+	for ns := range e.MissingVerbsByNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	var parts []string
+	for _, ns := range namespaces {
+		label := ns
+		if label == "" {
+			label = "<cluster>"
+		}
+		parts = append(parts, fmt.Sprintf("%s: missing %s", label, strings.Join(e.MissingVerbsByNamespace[ns], ", ")))
+	}
+	return fmt.Sprintf("prerequisites not satisfied: %s", strings.Join(parts, "; "))
+}
+
+// Unwrap allows errors.Is(err, ErrPrerequisitesFailed) to succeed for any
+// PrerequisitesError.
+func (e *PrerequisitesError) Unwrap() error {
+	return ErrPrerequisitesFailed
+}
+
+// AccessChecker abstracts the authorization API call PrerequisiteChecker
+// needs: can the caller perform verb on resource in namespace (namespace
+// is empty for a cluster-scoped check)? It is satisfied by a thin wrapper
+// around SelfSubjectAccessReview/SubjectAccessReview so this package
+// doesn't have to hard-code which of the two the caller wants to use.
+type AccessChecker interface {
+	Allowed(ctx context.Context, namespace, resource, verb string) (bool, error)
+}
+
+// PrerequisiteChecker gates controller startup on the CRDs/resources and
+// RBAC permissions it depends on, so operators can log a warning and
+// disable features instead of crash-looping when a dependency is missing.
+type PrerequisiteChecker struct {
+	resources ResourcesInterface
+	access    AccessChecker
+}
+
+// NewPrerequisiteChecker returns a PrerequisiteChecker that uses resources
+// to verify a resource is registered and access to verify RBAC permissions.
+func NewPrerequisiteChecker(resources ResourcesInterface, access AccessChecker) *PrerequisiteChecker {
+	return &PrerequisiteChecker{resources: resources, access: access}
+}
+
+// NewPrerequisiteCheckerForClient returns a PrerequisiteChecker wired to
+// client's own discovery and a SelfSubjectAccessReview-backed AccessChecker,
+// the combination most controllers actually want.
+func NewPrerequisiteCheckerForClient(client *Client) *PrerequisiteChecker {
+	return NewPrerequisiteChecker(client, NewSelfSubjectAccessChecker(client))
+}
+
+// selfSubjectAccessReview, selfSubjectAccessReviewSpec, resourceAttributes
+// and selfSubjectAccessReviewStatus mirror the fields of the
+// authorization.k8s.io SelfSubjectAccessReview this package actually needs
+// to send and read; they exist here (rather than importing the full
+// authorization API types) purely to keep the default AccessChecker
+// self-contained.
+type selfSubjectAccessReview struct {
+	Spec   selfSubjectAccessReviewSpec   `json:"spec"`
+	Status selfSubjectAccessReviewStatus `json:"status,omitempty"`
+}
+
+type selfSubjectAccessReviewSpec struct {
+	ResourceAttributes *resourceAttributes `json:"resourceAttributes,omitempty"`
+}
+
+type resourceAttributes struct {
+	Namespace string `json:"namespace,omitempty"`
+	Verb      string `json:"verb,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+}
+
+type selfSubjectAccessReviewStatus struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// selfSubjectAccessChecker is the default AccessChecker: it asks the server
+// whether the caller's own credentials permit verb on resource by POSTing a
+// SelfSubjectAccessReview, the same call `kubectl auth can-i` makes.
+type selfSubjectAccessChecker struct {
+	client *Client
+}
+
+// NewSelfSubjectAccessChecker returns an AccessChecker backed by
+// SelfSubjectAccessReview.
+func NewSelfSubjectAccessChecker(client *Client) AccessChecker {
+	return &selfSubjectAccessChecker{client: client}
+}
+
+func (a *selfSubjectAccessChecker) Allowed(ctx context.Context, namespace, resource, verb string) (bool, error) {
+	review := &selfSubjectAccessReview{
+		Spec: selfSubjectAccessReviewSpec{
+			ResourceAttributes: &resourceAttributes{
+				Namespace: namespace,
+				Verb:      verb,
+				Resource:  resource,
+			},
+		},
+	}
+	result := &selfSubjectAccessReview{}
+	err := a.client.Post().
+		AbsPath("/apis/authorization.k8s.io/v1beta1/selfsubjectaccessreviews").
+		Body(review).
+		Do().
+		Into(result)
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+// CheckPrerequisites verifies that groupVersion/resource is registered on
+// the server and that the caller holds every verb in requiredVerbs (keyed
+// by resource name) in each of namespaces (cluster scope if namespaces is
+// empty). It returns a *PrerequisitesError (matching ErrPrerequisitesFailed)
+// describing what's missing, or nil if every prerequisite is satisfied.
+// Errors from the discovery lookup or the AccessChecker are returned as
+// they are, not wrapped in a *PrerequisitesError.
+func (p *PrerequisiteChecker) CheckPrerequisites(ctx context.Context, namespaces []string, requiredVerbs map[string][]string, groupVersion, resource string) error {
+	list, err := p.resources.SupportedResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return err
+	}
+	if !hasResource(list.APIResources, resource) {
+		return &PrerequisitesError{MissingResource: groupVersion + "/" + resource}
+	}
+
+	scopes := namespaces
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+
+	missing := map[string][]string{}
+	for _, ns := range scopes {
+		for res, verbs := range requiredVerbs {
+			for _, verb := range verbs {
+				allowed, err := p.access.Allowed(ctx, ns, res, verb)
+				if err != nil {
+					return err
+				}
+				if !allowed {
+					missing[ns] = append(missing[ns], res+":"+verb)
+				}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return &PrerequisitesError{MissingVerbsByNamespace: missing}
+	}
+	return nil
+}
+
+func hasResource(resources []unversioned.APIResource, name string) bool {
+	for _, r := range resources {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitForCRD polls resources until groupVersion/resource is registered, or
+// timeout elapses. Callers typically use this right after applying a CRD
+// manifest and before starting a controller that depends on it.
+func WaitForCRD(ctx context.Context, resources ResourcesInterface, groupVersion, resource string, pollInterval, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	check := func() (bool, error) {
+		list, err := resources.SupportedResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			// The group/version itself may not exist yet; treat that the
+			// same as the resource not being ready rather than failing.
+			return false, nil
+		}
+		return hasResource(list.APIResources, resource), nil
+	}
+
+	if ok, err := check(); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("timed out waiting for %s/%s to be registered", groupVersion, resource)
+		case <-ticker.C:
+			ok, err := check()
+			if err != nil {
+				return err
+			}
+			if ok {
+				return nil
+			}
+		}
+	}
+}
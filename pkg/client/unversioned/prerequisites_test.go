@@ -0,0 +1,210 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+func TestPrerequisitesErrorMessages(t *testing.T) {
+	missingResource := &PrerequisitesError{MissingResource: "apps/v1/deployments"}
+	want := `prerequisites not satisfied: resource "apps/v1/deployments" is not registered on the server`
+	if got := missingResource.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	missingVerbs := &PrerequisitesError{
+		MissingVerbsByNamespace: map[string][]string{
+			"":            {"deployments:list"},
+			"kube-system": {"deployments:watch"},
+		},
+	}
+	want = `prerequisites not satisfied: <cluster>: missing deployments:list; kube-system: missing deployments:watch`
+	if got := missingVerbs.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestPrerequisitesErrorUnwrapsToSentinel(t *testing.T) {
+	err := &PrerequisitesError{MissingResource: "v1/pods"}
+	if !errors.Is(err, ErrPrerequisitesFailed) {
+		t.Errorf("errors.Is(err, ErrPrerequisitesFailed) = false, want true")
+	}
+}
+
+// fakeResources is a ResourcesInterface backed by a fixed table, with an
+// optional error to simulate the group/version itself being unreachable.
+type fakeResources struct {
+	lists map[string]*unversioned.APIResourceList
+	err   error
+}
+
+func (f *fakeResources) SupportedResourcesForGroupVersion(groupVersion string) (*unversioned.APIResourceList, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	list, ok := f.lists[groupVersion]
+	if !ok {
+		return nil, errors.New("group version not found")
+	}
+	return list, nil
+}
+
+// fakeAccessChecker grants every (namespace, resource, verb) triple present
+// in allowed, and rejects everything else; err, if set, is returned instead
+// of consulting the table at all.
+type fakeAccessChecker struct {
+	allowed map[string]bool
+	err     error
+}
+
+func (f *fakeAccessChecker) Allowed(ctx context.Context, namespace, resource, verb string) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.allowed[namespace+"/"+resource+":"+verb], nil
+}
+
+func TestCheckPrerequisitesMissingResource(t *testing.T) {
+	resources := &fakeResources{lists: map[string]*unversioned.APIResourceList{
+		"apps/v1": {GroupVersion: "apps/v1"},
+	}}
+	checker := NewPrerequisiteChecker(resources, &fakeAccessChecker{})
+
+	err := checker.CheckPrerequisites(context.Background(), nil, nil, "apps/v1", "deployments")
+	var prereqErr *PrerequisitesError
+	if !errors.As(err, &prereqErr) {
+		t.Fatalf("CheckPrerequisites() error = %v, want *PrerequisitesError", err)
+	}
+	if prereqErr.MissingResource != "apps/v1/deployments" {
+		t.Errorf("MissingResource = %q, want %q", prereqErr.MissingResource, "apps/v1/deployments")
+	}
+}
+
+func TestCheckPrerequisitesAggregatesMissingVerbsPerNamespace(t *testing.T) {
+	resources := &fakeResources{lists: map[string]*unversioned.APIResourceList{
+		"apps/v1": {APIResources: []unversioned.APIResource{{Name: "deployments"}}},
+	}}
+	access := &fakeAccessChecker{allowed: map[string]bool{
+		"ns1/deployments:list": true,
+		"ns2/deployments:list": true,
+		// "deployments:watch" is withheld in both namespaces.
+	}}
+	checker := NewPrerequisiteChecker(resources, access)
+
+	err := checker.CheckPrerequisites(
+		context.Background(),
+		[]string{"ns1", "ns2"},
+		map[string][]string{"deployments": {"list", "watch"}},
+		"apps/v1", "deployments",
+	)
+	var prereqErr *PrerequisitesError
+	if !errors.As(err, &prereqErr) {
+		t.Fatalf("CheckPrerequisites() error = %v, want *PrerequisitesError", err)
+	}
+	for _, ns := range []string{"ns1", "ns2"} {
+		verbs := prereqErr.MissingVerbsByNamespace[ns]
+		if len(verbs) != 1 || verbs[0] != "deployments:watch" {
+			t.Errorf("MissingVerbsByNamespace[%q] = %v, want [\"deployments:watch\"]", ns, verbs)
+		}
+	}
+}
+
+func TestCheckPrerequisitesPropagatesAccessCheckerError(t *testing.T) {
+	resources := &fakeResources{lists: map[string]*unversioned.APIResourceList{
+		"apps/v1": {APIResources: []unversioned.APIResource{{Name: "deployments"}}},
+	}}
+	boom := errors.New("boom")
+	checker := NewPrerequisiteChecker(resources, &fakeAccessChecker{err: boom})
+
+	err := checker.CheckPrerequisites(context.Background(), nil, map[string][]string{"deployments": {"list"}}, "apps/v1", "deployments")
+	if !errors.Is(err, boom) {
+		t.Errorf("CheckPrerequisites() error = %v, want the AccessChecker's error to propagate", err)
+	}
+}
+
+func TestCheckPrerequisitesSatisfied(t *testing.T) {
+	resources := &fakeResources{lists: map[string]*unversioned.APIResourceList{
+		"apps/v1": {APIResources: []unversioned.APIResource{{Name: "deployments"}}},
+	}}
+	access := &fakeAccessChecker{allowed: map[string]bool{"/deployments:list": true}}
+	checker := NewPrerequisiteChecker(resources, access)
+
+	if err := checker.CheckPrerequisites(context.Background(), nil, map[string][]string{"deployments": {"list"}}, "apps/v1", "deployments"); err != nil {
+		t.Errorf("CheckPrerequisites() error = %v, want nil", err)
+	}
+}
+
+func TestWaitForCRDReturnsImmediatelyWhenAlreadyRegistered(t *testing.T) {
+	resources := &fakeResources{lists: map[string]*unversioned.APIResourceList{
+		"apps/v1": {APIResources: []unversioned.APIResource{{Name: "deployments"}}},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := WaitForCRD(ctx, resources, "apps/v1", "deployments", time.Millisecond, time.Second); err != nil {
+		t.Errorf("WaitForCRD() error = %v, want nil", err)
+	}
+}
+
+func TestWaitForCRDPollsUntilRegistered(t *testing.T) {
+	resources := &fakeResources{err: errors.New("apps/v1 not registered yet")}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		resources.err = nil
+		resources.lists = map[string]*unversioned.APIResourceList{
+			"apps/v1": {APIResources: []unversioned.APIResource{{Name: "deployments"}}},
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := WaitForCRD(ctx, resources, "apps/v1", "deployments", time.Millisecond, time.Second); err != nil {
+		t.Errorf("WaitForCRD() error = %v, want nil once registration appears", err)
+	}
+}
+
+func TestWaitForCRDTimesOut(t *testing.T) {
+	resources := &fakeResources{err: errors.New("apps/v1 never shows up")}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := WaitForCRD(ctx, resources, "apps/v1", "deployments", time.Millisecond, 10*time.Millisecond)
+	if err == nil {
+		t.Errorf("WaitForCRD() error = nil, want a timeout error")
+	}
+}
+
+func TestWaitForCRDRespectsContextCancellation(t *testing.T) {
+	resources := &fakeResources{err: errors.New("apps/v1 never shows up")}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+
+	err := WaitForCRD(ctx, resources, "apps/v1", "deployments", time.Millisecond, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitForCRD() error = %v, want context.Canceled", err)
+	}
+}
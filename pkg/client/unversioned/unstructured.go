@@ -0,0 +1,181 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/discovery"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+// UnstructuredInterface lets callers work with resources the client was not
+// compiled against -- CRDs and any other kind the server advertises through
+// discovery -- by reading and writing them as generic, map-backed objects
+// instead of generated Go structs.
+type UnstructuredInterface interface {
+	// Resource returns a client scoped to the given GroupVersionResource.
+	// Call .Namespace() on the result before using it if the resource is
+	// namespaced.
+	Resource(gvr unversioned.GroupVersionResource) UnstructuredResourceClient
+}
+
+// UnstructuredResourceClient performs the usual verbs against a single
+// GroupVersionResource, optionally scoped to a namespace.
+type UnstructuredResourceClient interface {
+	Namespace(namespace string) UnstructuredResourceClient
+
+	Get(name string) (*runtime.Unstructured, error)
+	List(opts api.ListOptions) (*runtime.UnstructuredList, error)
+	Create(obj *runtime.Unstructured) (*runtime.Unstructured, error)
+	Update(obj *runtime.Unstructured) (*runtime.Unstructured, error)
+	Patch(name string, pt api.PatchType, data []byte) (*runtime.Unstructured, error)
+	Delete(name string, opts *api.DeleteOptions) error
+	Watch(opts api.ListOptions) (watch.Interface, error)
+}
+
+// unstructuredClient is the RESTMapper-aware implementation of
+// UnstructuredInterface/UnstructuredResourceClient backed by a Client.
+type unstructuredClient struct {
+	client    *Client
+	mapper    discovery.RESTMapper
+	gvr       unversioned.GroupVersionResource
+	namespace string
+}
+
+// Resource implements UnstructuredInterface on Client itself, resolving URL
+// prefixes and namespaced scope from a RESTMapper built over c.Discovery()
+// (memoized the same way Discovery() and Capabilities() are) rather than
+// generated code, so callers don't need to construct their own mapper.
+func (c *Client) Resource(gvr unversioned.GroupVersionResource) UnstructuredResourceClient {
+	c.unstructuredOnce.Do(func() {
+		c.unstructuredMapper = discovery.NewDeferredDiscoveryRESTMapper(c.Discovery())
+	})
+	return &unstructuredClient{client: c, mapper: c.unstructuredMapper, gvr: gvr}
+}
+
+func (u *unstructuredClient) Resource(gvr unversioned.GroupVersionResource) UnstructuredResourceClient {
+	return &unstructuredClient{client: u.client, mapper: u.mapper, gvr: gvr}
+}
+
+func (u *unstructuredClient) Namespace(namespace string) UnstructuredResourceClient {
+	ns := *u
+	ns.namespace = namespace
+	return &ns
+}
+
+// path returns the URL path segments for this resource: "/api/<version>"
+// for the legacy core group or "/apis/<group>/<version>" otherwise, followed
+// by "namespaces/<ns>" when the resource is namespaced and a namespace was
+// set, followed by the resource name itself.
+func (u *unstructuredClient) path() ([]string, error) {
+	segments := []string{"/apis"}
+	if u.gvr.Group == "" {
+		segments = []string{"/api"}
+	} else {
+		segments = append(segments, u.gvr.Group)
+	}
+	segments = append(segments, u.gvr.Version)
+
+	if u.namespace != "" {
+		namespaced, err := u.mapper.Namespaced(u.gvr)
+		if err != nil {
+			return nil, err
+		}
+		if namespaced {
+			segments = append(segments, "namespaces", u.namespace)
+		}
+	}
+	return append(segments, u.gvr.Resource), nil
+}
+
+func (u *unstructuredClient) Get(name string) (*runtime.Unstructured, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	obj := &runtime.Unstructured{}
+	if err := u.client.Get().AbsPath(append(segments, name)...).Do().Into(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (u *unstructuredClient) List(opts api.ListOptions) (*runtime.UnstructuredList, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	list := &runtime.UnstructuredList{}
+	if err := u.client.Get().AbsPath(segments...).VersionedParams(&opts, api.ParameterCodec).Do().Into(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (u *unstructuredClient) Create(obj *runtime.Unstructured) (*runtime.Unstructured, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	result := &runtime.Unstructured{}
+	if err := u.client.Post().AbsPath(segments...).Body(obj).Do().Into(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *unstructuredClient) Update(obj *runtime.Unstructured) (*runtime.Unstructured, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	result := &runtime.Unstructured{}
+	if err := u.client.Put().AbsPath(append(segments, obj.GetName())...).Body(obj).Do().Into(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *unstructuredClient) Patch(name string, pt api.PatchType, data []byte) (*runtime.Unstructured, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	result := &runtime.Unstructured{}
+	if err := u.client.Patch(pt).AbsPath(append(segments, name)...).Body(data).Do().Into(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (u *unstructuredClient) Delete(name string, opts *api.DeleteOptions) error {
+	segments, err := u.path()
+	if err != nil {
+		return err
+	}
+	return u.client.Delete().AbsPath(append(segments, name)...).Body(opts).Do().Error()
+}
+
+func (u *unstructuredClient) Watch(opts api.ListOptions) (watch.Interface, error) {
+	segments, err := u.path()
+	if err != nil {
+		return nil, err
+	}
+	return u.client.Get().AbsPath(segments...).VersionedParams(&opts, api.ParameterCodec).Watch()
+}
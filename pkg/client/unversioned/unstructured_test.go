@@ -0,0 +1,133 @@
+/*
+Copyright 2014 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unversioned
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/discovery"
+)
+
+// fakeRESTMapper answers Namespaced() from a fixed table and is otherwise
+// unused by path(), which is all these tests exercise.
+type fakeRESTMapper struct {
+	namespaced map[unversioned.GroupVersionResource]bool
+	err        error
+}
+
+func (f *fakeRESTMapper) KindFor(resource string) (unversioned.GroupVersionKind, error) {
+	return unversioned.GroupVersionKind{}, errors.New("not implemented")
+}
+
+func (f *fakeRESTMapper) ResourceFor(kind unversioned.GroupVersionKind) (unversioned.GroupVersionResource, error) {
+	return unversioned.GroupVersionResource{}, errors.New("not implemented")
+}
+
+func (f *fakeRESTMapper) ResourceSingularizer(resource string) string {
+	return resource
+}
+
+func (f *fakeRESTMapper) Namespaced(gvr unversioned.GroupVersionResource) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	return f.namespaced[gvr], nil
+}
+
+var _ discovery.RESTMapper = &fakeRESTMapper{}
+
+func TestUnstructuredClientPathCoreNamespaced(t *testing.T) {
+	gvr := unversioned.GroupVersionResource{Version: "v1", Resource: "pods"}
+	u := &unstructuredClient{
+		mapper:    &fakeRESTMapper{namespaced: map[unversioned.GroupVersionResource]bool{gvr: true}},
+		gvr:       gvr,
+		namespace: "ns1",
+	}
+
+	got, err := u.path()
+	if err != nil {
+		t.Fatalf("path() error = %v", err)
+	}
+	want := []string{"/api", "v1", "namespaces", "ns1", "pods"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("path() = %v, want %v", got, want)
+	}
+}
+
+func TestUnstructuredClientPathCoreClusterScoped(t *testing.T) {
+	gvr := unversioned.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	u := &unstructuredClient{
+		mapper: &fakeRESTMapper{namespaced: map[unversioned.GroupVersionResource]bool{gvr: false}},
+		gvr:    gvr,
+	}
+
+	got, err := u.path()
+	if err != nil {
+		t.Fatalf("path() error = %v", err)
+	}
+	want := []string{"/api", "v1", "nodes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("path() = %v, want %v", got, want)
+	}
+}
+
+func TestUnstructuredClientPathGroupNamespaced(t *testing.T) {
+	gvr := unversioned.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	u := &unstructuredClient{
+		mapper:    &fakeRESTMapper{namespaced: map[unversioned.GroupVersionResource]bool{gvr: true}},
+		gvr:       gvr,
+		namespace: "default",
+	}
+
+	got, err := u.path()
+	if err != nil {
+		t.Fatalf("path() error = %v", err)
+	}
+	want := []string{"/apis", "apps", "v1", "namespaces", "default", "deployments"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("path() = %v, want %v", got, want)
+	}
+}
+
+func TestUnstructuredClientPathNamespaceLookupError(t *testing.T) {
+	gvr := unversioned.GroupVersionResource{Version: "v1", Resource: "pods"}
+	u := &unstructuredClient{
+		mapper:    &fakeRESTMapper{err: errors.New("discovery unavailable")},
+		gvr:       gvr,
+		namespace: "ns1",
+	}
+
+	if _, err := u.path(); err == nil {
+		t.Errorf("path() succeeded, want the RESTMapper's error to propagate")
+	}
+}
+
+func TestUnstructuredClientNamespaceIsImmutable(t *testing.T) {
+	gvr := unversioned.GroupVersionResource{Version: "v1", Resource: "pods"}
+	base := &unstructuredClient{mapper: &fakeRESTMapper{}, gvr: gvr}
+
+	scoped := base.Namespace("ns1")
+	if base.namespace != "" {
+		t.Errorf("Namespace() mutated the receiver's namespace to %q", base.namespace)
+	}
+	if got := scoped.(*unstructuredClient).namespace; got != "ns1" {
+		t.Errorf("Namespace(\"ns1\") namespace = %q, want \"ns1\"", got)
+	}
+}